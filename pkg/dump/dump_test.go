@@ -0,0 +1,111 @@
+package dump
+
+import (
+	"testing"
+)
+
+type testPayload struct {
+	Name  string
+	Value int
+}
+
+func TestEncodeDecode_RoundTrip(t *testing.T) {
+	for _, codec := range []string{"cbor", "json"} {
+		t.Run(codec, func(t *testing.T) {
+			in := testPayload{Name: "alice", Value: 42}
+
+			data, err := Encode(3, codec, in)
+			if err != nil {
+				t.Fatalf("Encode: %v", err)
+			}
+
+			var out testPayload
+			header, err := Decode(data, &out)
+			if err != nil {
+				t.Fatalf("Decode: %v", err)
+			}
+
+			if header.Magic != Magic {
+				t.Fatalf("header.Magic = %q, want %q", header.Magic, Magic)
+			}
+			if header.Version != 3 {
+				t.Fatalf("header.Version = %d, want 3", header.Version)
+			}
+			if header.Codec != codec {
+				t.Fatalf("header.Codec = %q, want %q", header.Codec, codec)
+			}
+			if out != in {
+				t.Fatalf("Decode() = %+v, want %+v", out, in)
+			}
+		})
+	}
+}
+
+func TestDecode_TruncatedCodecNameReturnsError(t *testing.T) {
+	data := []byte(Magic)
+	data = append(data, 0, 0, 0, 7) // version
+	data = append(data, 200)       // codec length longer than the remaining data
+
+	var out testPayload
+	if _, err := Decode(data, &out); err != ErrTruncated {
+		t.Fatalf("Decode(truncated codec) = %v, want ErrTruncated", err)
+	}
+}
+
+func TestDetectVersion_HeaderlessDataIsV0(t *testing.T) {
+	oldFormat := []byte("1;+992000000001;500|2;+992000000002;100|")
+	if version := DetectVersion(oldFormat); version != 0 {
+		t.Fatalf("DetectVersion(headerless) = %d, want 0", version)
+	}
+}
+
+func TestDetectVersion_ReadsHeaderedVersion(t *testing.T) {
+	data, err := Encode(7, "json", testPayload{Name: "bob", Value: 1})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+	if version := DetectVersion(data); version != 7 {
+		t.Fatalf("DetectVersion() = %d, want 7", version)
+	}
+}
+
+func TestMigrate_AppliesRegisteredMigratorsInSequence(t *testing.T) {
+	RegisterMigrator(100, 101, func(old []byte) ([]byte, error) {
+		var payload testPayload
+		if _, err := Decode(old, &payload); err != nil {
+			return nil, err
+		}
+		payload.Value++
+		return Encode(101, "json", payload)
+	})
+	RegisterMigrator(101, 102, func(old []byte) ([]byte, error) {
+		var payload testPayload
+		if _, err := Decode(old, &payload); err != nil {
+			return nil, err
+		}
+		payload.Value++
+		return Encode(102, "json", payload)
+	})
+
+	data, err := Encode(100, "json", testPayload{Name: "carol", Value: 0})
+	if err != nil {
+		t.Fatalf("Encode: %v", err)
+	}
+
+	migrated, err := Migrate(data, 100, 102)
+	if err != nil {
+		t.Fatalf("Migrate: %v", err)
+	}
+
+	var out testPayload
+	header, err := Decode(migrated, &out)
+	if err != nil {
+		t.Fatalf("Decode migrated: %v", err)
+	}
+	if header.Version != 102 {
+		t.Fatalf("header.Version = %d, want 102", header.Version)
+	}
+	if out.Value != 2 {
+		t.Fatalf("out.Value = %d, want 2 (two migrators each incrementing once)", out.Value)
+	}
+}
@@ -0,0 +1,70 @@
+// Package parallel generalizes the goroutine-sharding pattern used across
+// wallet's aggregation helpers (SumPayments, FilterPayments, ...) into a
+// single reusable engine.
+package parallel
+
+import (
+	"runtime"
+	"sync"
+)
+
+// Mapper computes a partial result for the half-open shard [lo, hi).
+type Mapper[T any] func(lo, hi int) T
+
+// Reducer combines two partial results into one, where a is the fold so
+// far over shards [0, i) and b is shard i's result, so order-sensitive
+// reducers (e.g. concatenating slices) produce the same result as a
+// sequential pass over [0, length) would.
+type Reducer[T any] func(a, b T) T
+
+// Aggregate splits [0, length) into goroutines shards, runs mapper over
+// each shard concurrently, and folds the partial results with reducer in
+// shard order (0, 1, 2, ...), regardless of which shard's goroutine
+// finishes first.
+//
+// goroutines <= 0 falls back to runtime.NumCPU(); goroutines > length is
+// capped to length so that no shard is empty. Any remainder from dividing
+// length by goroutines is spread one-by-one across the first shards,
+// rather than dumped entirely onto the last one.
+func Aggregate[T any](length, goroutines int, mapper Mapper[T], reducer Reducer[T]) T {
+	var zero T
+	if length <= 0 {
+		return zero
+	}
+
+	if goroutines <= 0 {
+		goroutines = runtime.NumCPU()
+	}
+	if goroutines > length {
+		goroutines = length
+	}
+
+	shard := length / goroutines
+	remainder := length % goroutines
+
+	results := make([]T, goroutines)
+	var wg sync.WaitGroup
+	wg.Add(goroutines)
+	lo := 0
+	for i := 0; i < goroutines; i++ {
+		hi := lo + shard
+		if i < remainder {
+			hi++
+		}
+
+		go func(i, lo, hi int) {
+			defer wg.Done()
+			results[i] = mapper(lo, hi)
+		}(i, lo, hi)
+
+		lo = hi
+	}
+	wg.Wait()
+
+	result := results[0]
+	for i := 1; i < goroutines; i++ {
+		result = reducer(result, results[i])
+	}
+
+	return result
+}
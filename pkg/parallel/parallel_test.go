@@ -0,0 +1,81 @@
+package parallel
+
+import "testing"
+
+func sumMapper(items []int64) Mapper[int64] {
+	return func(lo, hi int) int64 {
+		var sum int64
+		for _, v := range items[lo:hi] {
+			sum += v
+		}
+		return sum
+	}
+}
+
+func sumReducer(a, b int64) int64 {
+	return a + b
+}
+
+func TestAggregate_MatchesSerialSum(t *testing.T) {
+	items := make([]int64, 0, 1001)
+	for i := 0; i < 1001; i++ {
+		items = append(items, int64(i))
+	}
+
+	var want int64
+	for _, v := range items {
+		want += v
+	}
+
+	for _, goroutines := range []int{0, 1, 3, 7, len(items), len(items) * 2} {
+		got := Aggregate(len(items), goroutines, sumMapper(items), sumReducer)
+		if got != want {
+			t.Fatalf("goroutines=%d: got %d, want %d", goroutines, got, want)
+		}
+	}
+}
+
+func TestAggregate_FoldsShardsInIndexOrder(t *testing.T) {
+	items := make([]int, 0, 1001)
+	for i := 0; i < 1001; i++ {
+		items = append(items, i)
+	}
+
+	mapper := func(lo, hi int) []int {
+		shard := make([]int, hi-lo)
+		copy(shard, items[lo:hi])
+		return shard
+	}
+	concat := func(a, b []int) []int {
+		return append(a, b...)
+	}
+
+	for _, goroutines := range []int{1, 3, 7, 16} {
+		got := Aggregate(len(items), goroutines, mapper, concat)
+		if len(got) != len(items) {
+			t.Fatalf("goroutines=%d: len(got) = %d, want %d", goroutines, len(got), len(items))
+		}
+		for i, v := range got {
+			if v != items[i] {
+				t.Fatalf("goroutines=%d: got[%d] = %d, want %d (shards not folded in index order)", goroutines, i, v, items[i])
+			}
+		}
+	}
+}
+
+func benchmarkAggregate(b *testing.B, goroutines int) {
+	items := make([]int64, 1_000_000)
+	for i := range items {
+		items[i] = int64(i)
+	}
+	mapper := sumMapper(items)
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Aggregate(len(items), goroutines, mapper, sumReducer)
+	}
+}
+
+func BenchmarkAggregate_Serial(b *testing.B)   { benchmarkAggregate(b, 1) }
+func BenchmarkAggregate_4Workers(b *testing.B) { benchmarkAggregate(b, 4) }
+func BenchmarkAggregate_NumCPU(b *testing.B)   { benchmarkAggregate(b, 0) }
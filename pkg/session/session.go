@@ -0,0 +1,246 @@
+package session
+
+import (
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/Nappy-Says/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+var ErrPairingNotFound = errors.New("pairing not found")
+var ErrPairingNotApproved = errors.New("pairing not approved")
+var ErrPairingExpired = errors.New("pairing expired")
+var ErrCategoryNotAllowed = errors.New("category not allowed for this pairing")
+var ErrSpendCapExceeded = errors.New("pairing spend cap exceeded")
+
+// Permissions scopes what a pairing is allowed to do on behalf of an
+// account: a maximum total spend and, if non-empty, a whitelist of
+// categories it may pay into.
+type Permissions struct {
+	MaxAmount  types.Money
+	Categories []types.PaymentCategory
+}
+
+func (p Permissions) allows(category types.PaymentCategory) bool {
+	if len(p.Categories) == 0 {
+		return true
+	}
+	for _, c := range p.Categories {
+		if c == category {
+			return true
+		}
+	}
+	return false
+}
+
+// Pairing is a temporary, scoped authorization for an external app to act
+// on accountID without holding its credentials, mirroring a WalletConnect
+// pairing session.
+type Pairing struct {
+	ID          string
+	AccountID   int64
+	Permissions Permissions
+	ExpiresAt   time.Time
+	Approved    bool
+	Spent       types.Money
+}
+
+func (p *Pairing) expired() bool {
+	return time.Now().After(p.ExpiresAt)
+}
+
+// Manager keeps track of active pairings and expires stale ones in the
+// background.
+type Manager struct {
+	mu       sync.RWMutex
+	pairings map[string]*Pairing
+	done     chan struct{}
+}
+
+// NewManager creates a Manager and starts its background janitor, which
+// prunes expired pairings every interval.
+func NewManager(interval time.Duration) *Manager {
+	m := &Manager{
+		pairings: make(map[string]*Pairing),
+		done:     make(chan struct{}),
+	}
+	go m.janitor(interval)
+	return m
+}
+
+func (m *Manager) janitor(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.mu.Lock()
+			for id, p := range m.pairings {
+				if p.expired() {
+					delete(m.pairings, id)
+				}
+			}
+			m.mu.Unlock()
+		case <-m.done:
+			return
+		}
+	}
+}
+
+// Close stops the background janitor goroutine.
+func (m *Manager) Close() {
+	close(m.done)
+}
+
+// Propose creates a new, unapproved Pairing for accountID scoped by
+// permissions and valid for ttl.
+func (m *Manager) Propose(accountID int64, permissions Permissions, ttl time.Duration) (*Pairing, error) {
+	pairing := &Pairing{
+		ID:          uuid.New().String(),
+		AccountID:   accountID,
+		Permissions: permissions,
+		ExpiresAt:   time.Now().Add(ttl),
+	}
+
+	m.mu.Lock()
+	m.pairings[pairing.ID] = pairing
+	m.mu.Unlock()
+
+	return pairing, nil
+}
+
+// Approve marks pairingID as approved, allowing it to be used by PayViaPairing.
+func (m *Manager) Approve(pairingID string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pairing, ok := m.pairings[pairingID]
+	if !ok {
+		return ErrPairingNotFound
+	}
+	if pairing.expired() {
+		delete(m.pairings, pairingID)
+		return ErrPairingExpired
+	}
+
+	pairing.Approved = true
+	return nil
+}
+
+// RecordSuccessful records a successful spend of amount against pairingID,
+// counting it towards the pairing's spend cap.
+func (m *Manager) RecordSuccessful(pairingID string, amount types.Money) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pairing, ok := m.pairings[pairingID]
+	if !ok {
+		return ErrPairingNotFound
+	}
+
+	pairing.Spent += amount
+	return nil
+}
+
+// HasActive reports whether accountID has at least one approved, unexpired
+// pairing.
+func (m *Manager) HasActive(accountID int64) bool {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, pairing := range m.pairings {
+		if pairing.AccountID == accountID && pairing.Approved && !pairing.expired() {
+			return true
+		}
+	}
+	return false
+}
+
+// Authorize validates that pairingID may be used to spend amount in
+// category, enforcing approval, expiry, category whitelist and spend cap.
+// It does not itself move any funds or count amount against the spend cap;
+// callers that go on to actually spend must use Reserve instead, or two
+// concurrent Authorize calls can both pass before either records its spend.
+func (m *Manager) Authorize(pairingID string, amount types.Money, category types.PaymentCategory) (*Pairing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	return m.checkLocked(pairingID, amount, category)
+}
+
+// Reserve atomically validates pairingID like Authorize and, if it passes,
+// immediately counts amount against the spend cap in the same critical
+// section, so concurrent callers can't both pass the check before either
+// commits. Call Release with the same amount if the payment that follows
+// fails, to give the reservation back.
+func (m *Manager) Reserve(pairingID string, amount types.Money, category types.PaymentCategory) (*Pairing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	pairing, err := m.checkLocked(pairingID, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	pairing.Spent += amount
+	return pairing, nil
+}
+
+// Release gives back a reservation made by Reserve whose payment failed, so
+// amount no longer counts against pairingID's spend cap.
+func (m *Manager) Release(pairingID string, amount types.Money) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if pairing, ok := m.pairings[pairingID]; ok {
+		pairing.Spent -= amount
+	}
+}
+
+// checkLocked runs the approval/expiry/category/spend-cap checks shared by
+// Authorize and Reserve. Callers must hold m.mu.
+func (m *Manager) checkLocked(pairingID string, amount types.Money, category types.PaymentCategory) (*Pairing, error) {
+	pairing, ok := m.pairings[pairingID]
+	if !ok {
+		return nil, ErrPairingNotFound
+	}
+	if pairing.expired() {
+		delete(m.pairings, pairingID)
+		return nil, ErrPairingExpired
+	}
+	if !pairing.Approved {
+		return nil, ErrPairingNotApproved
+	}
+	if !pairing.Permissions.allows(category) {
+		return nil, ErrCategoryNotAllowed
+	}
+	if pairing.Permissions.MaxAmount > 0 && pairing.Spent+amount > pairing.Permissions.MaxAmount {
+		return nil, ErrSpendCapExceeded
+	}
+
+	return pairing, nil
+}
+
+// All returns every pairing currently tracked, for persistence.
+func (m *Manager) All() []*Pairing {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	result := make([]*Pairing, 0, len(m.pairings))
+	for _, pairing := range m.pairings {
+		result = append(result, pairing)
+	}
+	return result
+}
+
+// Restore re-populates the Manager with pairings loaded from disk.
+func (m *Manager) Restore(pairings []*Pairing) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, pairing := range pairings {
+		m.pairings[pairing.ID] = pairing
+	}
+}
@@ -0,0 +1,105 @@
+package session
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+func TestManager_AuthorizeEnforcesApprovalAndSpendCap(t *testing.T) {
+	m := NewManager(time.Hour)
+	defer m.Close()
+
+	pairing, err := m.Propose(1, Permissions{MaxAmount: 100}, time.Minute)
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+
+	if _, err := m.Authorize(pairing.ID, 10, "food"); err != ErrPairingNotApproved {
+		t.Fatalf("Authorize before approval: got %v, want ErrPairingNotApproved", err)
+	}
+
+	if err := m.Approve(pairing.ID); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	if _, err := m.Authorize(pairing.ID, 150, "food"); err != ErrSpendCapExceeded {
+		t.Fatalf("Authorize over cap: got %v, want ErrSpendCapExceeded", err)
+	}
+
+	if _, err := m.Authorize(pairing.ID, 60, "food"); err != nil {
+		t.Fatalf("Authorize within cap: %v", err)
+	}
+	if err := m.RecordSuccessful(pairing.ID, 60); err != nil {
+		t.Fatalf("RecordSuccessful: %v", err)
+	}
+
+	if _, err := m.Authorize(pairing.ID, 60, "food"); err != ErrSpendCapExceeded {
+		t.Fatalf("Authorize after spend: got %v, want ErrSpendCapExceeded", err)
+	}
+}
+
+func TestManager_AuthorizeEnforcesCategoryWhitelist(t *testing.T) {
+	m := NewManager(time.Hour)
+	defer m.Close()
+
+	pairing, err := m.Propose(1, Permissions{Categories: []types.PaymentCategory{"food"}}, time.Minute)
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if err := m.Approve(pairing.ID); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	if _, err := m.Authorize(pairing.ID, 10, "auto"); err != ErrCategoryNotAllowed {
+		t.Fatalf("Authorize wrong category: got %v, want ErrCategoryNotAllowed", err)
+	}
+	if _, err := m.Authorize(pairing.ID, 10, "food"); err != nil {
+		t.Fatalf("Authorize allowed category: %v", err)
+	}
+}
+
+func TestManager_ReserveThenReleaseGivesBackSpendCap(t *testing.T) {
+	m := NewManager(time.Hour)
+	defer m.Close()
+
+	pairing, err := m.Propose(1, Permissions{MaxAmount: 100}, time.Minute)
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+	if err := m.Approve(pairing.ID); err != nil {
+		t.Fatalf("Approve: %v", err)
+	}
+
+	if _, err := m.Reserve(pairing.ID, 60, "food"); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if _, err := m.Reserve(pairing.ID, 60, "food"); err != ErrSpendCapExceeded {
+		t.Fatalf("Reserve over cap: got %v, want ErrSpendCapExceeded", err)
+	}
+
+	m.Release(pairing.ID, 60)
+
+	if _, err := m.Reserve(pairing.ID, 60, "food"); err != nil {
+		t.Fatalf("Reserve after Release: %v", err)
+	}
+}
+
+func TestManager_JanitorExpiresStalePairings(t *testing.T) {
+	m := NewManager(time.Millisecond)
+	defer m.Close()
+
+	pairing, err := m.Propose(1, Permissions{}, time.Millisecond)
+	if err != nil {
+		t.Fatalf("Propose: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for len(m.All()) > 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("pairing %s was not pruned by the janitor", pairing.ID)
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
@@ -0,0 +1,411 @@
+// Package store abstracts how wallet accounts are persisted and looked up,
+// so that Service can be backed by a flat non-deterministic store, a
+// hierarchical-deterministic one deriving IDs from a seed and path, or a
+// distributed/threshold store splitting authorization across N shares.
+package store
+
+import (
+	"encoding/binary"
+	"errors"
+	"hash/fnv"
+	"sync"
+
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+var ErrPhoneRegistered = errors.New("phone already registred")
+var ErrAccountNotFound = errors.New("account not found")
+var ErrAmountMustBePositive = errors.New("amount must be greater than zero")
+var ErrNotEnoughShares = errors.New("not enough signature shares")
+var ErrNotEnoughBalance = errors.New("account not enough balance")
+
+// AccountStore abstracts registering, looking up and depositing into
+// accounts, independent of how they're actually derived or stored.
+type AccountStore interface {
+	Register(phone types.Phone) (*types.Account, error)
+	FindByID(accountID int64) (*types.Account, error)
+	Deposit(accountID int64, amount types.Money) error
+	// Withdraw atomically checks and decrements accountID's balance by
+	// amount, returning ErrNotEnoughBalance rather than leaving the
+	// balance negative.
+	Withdraw(accountID int64, amount types.Money) error
+	Accounts() []*types.Account
+	// Put inserts or overwrites an account with an explicit ID, for
+	// restoring a store from a dump.
+	Put(account *types.Account)
+}
+
+// NDStore is a flat, non-deterministic store: the current behavior of
+// Service, with IDs assigned sequentially on registration.
+type NDStore struct {
+	mu            sync.Mutex
+	nextAccountID int64
+	byID          map[int64]*types.Account
+	byPhone       map[types.Phone]int64
+}
+
+func NewNDStore() *NDStore {
+	return &NDStore{
+		byID:    make(map[int64]*types.Account),
+		byPhone: make(map[types.Phone]int64),
+	}
+}
+
+func (s *NDStore) Register(phone types.Phone) (*types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byPhone[phone]; ok {
+		return nil, ErrPhoneRegistered
+	}
+
+	s.nextAccountID++
+	account := &types.Account{ID: s.nextAccountID, Phone: phone}
+	s.byID[account.ID] = account
+	s.byPhone[phone] = account.ID
+	return account, nil
+}
+
+// FindByID returns a copy of accountID's account, safe for the caller to
+// read without racing the store's own writes to the live account.
+func (s *NDStore) FindByID(accountID int64) (*types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (s *NDStore) Deposit(accountID int64, amount types.Money) error {
+	if amount < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	account.Balance += amount
+	return nil
+}
+
+func (s *NDStore) Withdraw(accountID int64, amount types.Money) error {
+	if amount < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	if account.Balance < amount {
+		return ErrNotEnoughBalance
+	}
+	account.Balance -= amount
+	return nil
+}
+
+// Accounts returns copies of every account in the store, safe for the
+// caller to read without racing the store's own writes to the live
+// accounts.
+func (s *NDStore) Accounts() []*types.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*types.Account, 0, len(s.byID))
+	for _, account := range s.byID {
+		copied := *account
+		out = append(out, &copied)
+	}
+	return out
+}
+
+func (s *NDStore) Put(account *types.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byID[account.ID]; ok {
+		*existing = *account
+	} else {
+		s.byID[account.ID] = account
+	}
+	s.byPhone[account.Phone] = account.ID
+	if account.ID > s.nextAccountID {
+		s.nextAccountID = account.ID
+	}
+}
+
+// HDStore is a hierarchical-deterministic store: account IDs are derived
+// from a seed and a derivation path rather than assigned sequentially, so
+// the same seed always re-derives the same account IDs.
+type HDStore struct {
+	mu       sync.Mutex
+	seed     []byte
+	nextPath uint32
+	byID     map[int64]*types.Account
+	byPhone  map[types.Phone]int64
+}
+
+func NewHDStore(seed []byte) *HDStore {
+	return &HDStore{
+		seed:    seed,
+		byID:    make(map[int64]*types.Account),
+		byPhone: make(map[types.Phone]int64),
+	}
+}
+
+// deriveAccountID derives a deterministic, positive account ID from the
+// store's seed and a derivation path index.
+func (s *HDStore) deriveAccountID(path uint32) int64 {
+	h := fnv.New64a()
+	h.Write(s.seed)
+	_ = binary.Write(h, binary.BigEndian, path)
+	return int64(h.Sum64() &^ (1 << 63))
+}
+
+func (s *HDStore) Register(phone types.Phone) (*types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byPhone[phone]; ok {
+		return nil, ErrPhoneRegistered
+	}
+
+	path := s.nextPath
+	s.nextPath++
+
+	account := &types.Account{ID: s.deriveAccountID(path), Phone: phone}
+	s.byID[account.ID] = account
+	s.byPhone[phone] = account.ID
+	return account, nil
+}
+
+// FindByID returns a copy of accountID's account, safe for the caller to
+// read without racing the store's own writes to the live account.
+func (s *HDStore) FindByID(accountID int64) (*types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (s *HDStore) Deposit(accountID int64, amount types.Money) error {
+	if amount < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	account.Balance += amount
+	return nil
+}
+
+func (s *HDStore) Withdraw(accountID int64, amount types.Money) error {
+	if amount < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	if account.Balance < amount {
+		return ErrNotEnoughBalance
+	}
+	account.Balance -= amount
+	return nil
+}
+
+// Accounts returns copies of every account in the store, safe for the
+// caller to read without racing the store's own writes to the live
+// accounts.
+func (s *HDStore) Accounts() []*types.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*types.Account, 0, len(s.byID))
+	for _, account := range s.byID {
+		copied := *account
+		out = append(out, &copied)
+	}
+	return out
+}
+
+func (s *HDStore) Put(account *types.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.byID[account.ID] = account
+	s.byPhone[account.Phone] = account.ID
+}
+
+// DistributedStore models a threshold wallet configured with N shares, of
+// which K are required to authorize a payment: Service.PayWithShares calls
+// Authorize before it decrements an account's balance. Authorize only counts
+// distinct share byte strings; shares are not verified or combined, and N is
+// not checked. Withdraw itself does not check shares.
+type DistributedStore struct {
+	mu      sync.Mutex
+	n, k    int
+	nextID  int64
+	byID    map[int64]*types.Account
+	byPhone map[types.Phone]int64
+}
+
+// NewDistributedStore creates a store requiring k of n shares to authorize
+// a payment.
+func NewDistributedStore(n, k int) *DistributedStore {
+	return &DistributedStore{
+		n:       n,
+		k:       k,
+		byID:    make(map[int64]*types.Account),
+		byPhone: make(map[types.Phone]int64),
+	}
+}
+
+func (s *DistributedStore) Register(phone types.Phone) (*types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.byPhone[phone]; ok {
+		return nil, ErrPhoneRegistered
+	}
+
+	s.nextID++
+	account := &types.Account{ID: s.nextID, Phone: phone}
+	s.byID[account.ID] = account
+	s.byPhone[phone] = account.ID
+	return account, nil
+}
+
+// FindByID returns a copy of accountID's account, safe for the caller to
+// read without racing the store's own writes to the live account.
+func (s *DistributedStore) FindByID(accountID int64) (*types.Account, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return nil, ErrAccountNotFound
+	}
+	copied := *account
+	return &copied, nil
+}
+
+func (s *DistributedStore) Deposit(accountID int64, amount types.Money) error {
+	if amount < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	account.Balance += amount
+	return nil
+}
+
+func (s *DistributedStore) Withdraw(accountID int64, amount types.Money) error {
+	if amount < 0 {
+		return ErrAmountMustBePositive
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	account, ok := s.byID[accountID]
+	if !ok {
+		return ErrAccountNotFound
+	}
+	if account.Balance < amount {
+		return ErrNotEnoughBalance
+	}
+	account.Balance -= amount
+	return nil
+}
+
+// Accounts returns copies of every account in the store, safe for the
+// caller to read without racing the store's own writes to the live
+// accounts.
+func (s *DistributedStore) Accounts() []*types.Account {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	out := make([]*types.Account, 0, len(s.byID))
+	for _, account := range s.byID {
+		copied := *account
+		out = append(out, &copied)
+	}
+	return out
+}
+
+func (s *DistributedStore) Put(account *types.Account) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if existing, ok := s.byID[account.ID]; ok {
+		*existing = *account
+	} else {
+		s.byID[account.ID] = account
+	}
+	s.byPhone[account.Phone] = account.ID
+	if account.ID > s.nextID {
+		s.nextID = account.ID
+	}
+}
+
+// Threshold reports the store's (n, k) share configuration.
+func (s *DistributedStore) Threshold() (n, k int) {
+	return s.n, s.k
+}
+
+// Authorize reports whether shares contains at least K distinct signature
+// shares, as required before a payment may decrement the account's balance.
+func (s *DistributedStore) Authorize(shares [][]byte) error {
+	distinct := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		distinct[string(share)] = true
+	}
+	if len(distinct) < s.k {
+		return ErrNotEnoughShares
+	}
+	return nil
+}
+
+// Convert copies every account from src into dst, mirroring the
+// split/combine of one account-store backend into another. It uses Put
+// rather than Register so that dst's accounts keep the same IDs as src's,
+// since Payments, Favorites and Pairings all reference accounts by that ID.
+func Convert(src, dst AccountStore) error {
+	for _, account := range src.Accounts() {
+		copied := *account
+		dst.Put(&copied)
+	}
+	return nil
+}
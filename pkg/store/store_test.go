@@ -0,0 +1,43 @@
+package store
+
+import "testing"
+
+func TestConvert_PreservesAccountIDsAndBalances(t *testing.T) {
+	src := NewNDStore()
+	account, err := src.Register("+992000000001")
+	if err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := src.Deposit(account.ID, 500); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	dst := NewHDStore([]byte("seed"))
+	if err := Convert(src, dst); err != nil {
+		t.Fatalf("Convert: %v", err)
+	}
+
+	converted, err := dst.FindByID(account.ID)
+	if err != nil {
+		t.Fatalf("FindByID(%d) on dst: %v", account.ID, err)
+	}
+	if converted.Phone != account.Phone {
+		t.Fatalf("converted.Phone = %q, want %q", converted.Phone, account.Phone)
+	}
+	if converted.Balance != account.Balance {
+		t.Fatalf("converted.Balance = %d, want %d", converted.Balance, account.Balance)
+	}
+
+	// Convert must copy, not alias: depositing into the converted account
+	// must not affect the source store.
+	if err := dst.Deposit(account.ID, 1); err != nil {
+		t.Fatalf("Deposit on dst: %v", err)
+	}
+	again, err := src.FindByID(account.ID)
+	if err != nil {
+		t.Fatalf("FindByID(%d) on src: %v", account.ID, err)
+	}
+	if again.Balance != converted.Balance {
+		t.Fatalf("src account aliases dst account after Convert")
+	}
+}
@@ -0,0 +1,25 @@
+package types
+
+// MultiTransactionKind describes the higher-level activity a group of
+// payments represents, as opposed to the raw Payment records themselves.
+type MultiTransactionKind int
+
+const (
+	MultiTransactionSend MultiTransactionKind = iota
+	MultiTransactionReceive
+	MultiTransactionSwap
+	MultiTransactionRefund
+)
+
+// MultiTransaction is a derived record grouping one or more Payments into a
+// single higher-level activity, e.g. a swap between two categories or a
+// refund of a previously rejected payment.
+type MultiTransaction struct {
+	ID         string
+	AccountID  int64
+	FromAsset  PaymentCategory
+	ToAsset    PaymentCategory
+	Kind       MultiTransactionKind
+	Amount     Money
+	PaymentIDs []string
+}
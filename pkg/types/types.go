@@ -0,0 +1,46 @@
+package types
+
+// Money is an amount in the smallest unit of account, e.g. cents.
+type Money int64
+
+// Phone is a phone number in whatever format the caller registered it in.
+type Phone string
+
+// PaymentCategory is a free-form label describing what a Payment was for,
+// e.g. "food" or "auto".
+type PaymentCategory string
+
+// PaymentStatus is the lifecycle state of a Payment.
+type PaymentStatus string
+
+const (
+	PaymentStatusOk         PaymentStatus = "OK"
+	PaymentStatusFail       PaymentStatus = "FAIL"
+	PaymentStatusInProgress PaymentStatus = "INPROGRESS"
+)
+
+// Account is a registered wallet holder, identified by phone number.
+type Account struct {
+	ID      int64
+	Phone   Phone
+	Balance Money
+}
+
+// Payment is a single payment made from an Account.
+type Payment struct {
+	ID        string
+	AccountID int64
+	Amount    Money
+	Category  PaymentCategory
+	Status    PaymentStatus
+}
+
+// Favorite is a saved Payment template that can be repeated without
+// specifying the amount and category again.
+type Favorite struct {
+	ID        string
+	AccountID int64
+	Name      string
+	Amount    Money
+	Category  PaymentCategory
+}
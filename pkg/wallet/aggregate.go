@@ -0,0 +1,90 @@
+package wallet
+
+import (
+	"github.com/Nappy-Says/wallet/pkg/parallel"
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+// SumPayments sums the amount of every payment, sharded across goroutines
+// workers.
+func (s *Service) SumPayments(goroutines int) types.Money {
+	payments := s.Snapshot().Payments
+
+	sum := parallel.Aggregate(len(payments), goroutines,
+		func(lo, hi int) types.Money {
+			var sum types.Money
+			for _, payment := range payments[lo:hi] {
+				sum += payment.Amount
+			}
+			return sum
+		},
+		func(a, b types.Money) types.Money {
+			return a + b
+		},
+	)
+
+	return sum
+}
+
+// FilterPayments returns every payment for which predicate returns true,
+// sharded across goroutines workers.
+func (s *Service) FilterPayments(goroutines int, predicate func(*types.Payment) bool) []*types.Payment {
+	payments := s.Snapshot().Payments
+
+	return parallel.Aggregate(len(payments), goroutines,
+		func(lo, hi int) []*types.Payment {
+			matched := make([]*types.Payment, 0)
+			for _, payment := range payments[lo:hi] {
+				if predicate(payment) {
+					matched = append(matched, payment)
+				}
+			}
+			return matched
+		},
+		func(a, b []*types.Payment) []*types.Payment {
+			return append(a, b...)
+		},
+	)
+}
+
+// SumByCategory sums the amount of every payment in category, sharded
+// across goroutines workers.
+func (s *Service) SumByCategory(goroutines int, category types.PaymentCategory) types.Money {
+	payments := s.Snapshot().Payments
+
+	return parallel.Aggregate(len(payments), goroutines,
+		func(lo, hi int) types.Money {
+			var sum types.Money
+			for _, payment := range payments[lo:hi] {
+				if payment.Category == category {
+					sum += payment.Amount
+				}
+			}
+			return sum
+		},
+		func(a, b types.Money) types.Money {
+			return a + b
+		},
+	)
+}
+
+// SumByAccount sums the amount of every payment on accountID, sharded
+// across goroutines workers.
+func (s *Service) SumByAccount(goroutines int, accountID int64) types.Money {
+	payments := s.Snapshot().Payments
+
+	return parallel.Aggregate(len(payments), goroutines,
+		func(lo, hi int) types.Money {
+			var sum types.Money
+			for _, payment := range payments[lo:hi] {
+				if payment.AccountID == accountID {
+					sum += payment.Amount
+				}
+			}
+			return sum
+		},
+		func(a, b types.Money) types.Money {
+			return a + b
+		},
+	)
+}
@@ -0,0 +1,117 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+func TestService_FilterPaymentsReturnsMatchingPaymentsInOrder(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	for i := 0; i < 20; i++ {
+		category := types.PaymentCategory("food")
+		if i%2 == 0 {
+			category = types.PaymentCategory("auto")
+		}
+		if _, err := svc.Pay(account.ID, 1, category); err != nil {
+			t.Fatalf("Pay: %v", err)
+		}
+	}
+
+	want := svc.Snapshot().Payments
+
+	for _, goroutines := range []int{1, 3, 8} {
+		got := svc.FilterPayments(goroutines, func(p *types.Payment) bool {
+			return p.Category == "food"
+		})
+
+		var wantFiltered []*types.Payment
+		for _, p := range want {
+			if p.Category == "food" {
+				wantFiltered = append(wantFiltered, p)
+			}
+		}
+
+		if len(got) != len(wantFiltered) {
+			t.Fatalf("goroutines=%d: len(got) = %d, want %d", goroutines, len(got), len(wantFiltered))
+		}
+		for i, p := range got {
+			if p.ID != wantFiltered[i].ID {
+				t.Fatalf("goroutines=%d: got[%d].ID = %q, want %q (payments not returned in snapshot order)", goroutines, i, p.ID, wantFiltered[i].ID)
+			}
+		}
+	}
+}
+
+func TestService_SumByCategorySumsOnlyMatchingPayments(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err := svc.Pay(account.ID, 10, "food"); err != nil {
+			t.Fatalf("Pay: %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := svc.Pay(account.ID, 20, "auto"); err != nil {
+			t.Fatalf("Pay: %v", err)
+		}
+	}
+
+	if sum := svc.SumByCategory(4, "food"); sum != 100 {
+		t.Fatalf("SumByCategory(food) = %d, want 100", sum)
+	}
+	if sum := svc.SumByCategory(4, "auto"); sum != 100 {
+		t.Fatalf("SumByCategory(auto) = %d, want 100", sum)
+	}
+}
+
+func TestService_SumByAccountSumsOnlyThatAccountsPayments(t *testing.T) {
+	svc := NewService(nil)
+	first, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	second, err := svc.RegisterAccount("+992000000002")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(first.ID, 1000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+	if err := svc.Deposit(second.ID, 1000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	for i := 0; i < 10; i++ {
+		if _, err := svc.Pay(first.ID, 10, "food"); err != nil {
+			t.Fatalf("Pay: %v", err)
+		}
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := svc.Pay(second.ID, 20, "food"); err != nil {
+			t.Fatalf("Pay: %v", err)
+		}
+	}
+
+	if sum := svc.SumByAccount(4, first.ID); sum != 100 {
+		t.Fatalf("SumByAccount(first) = %d, want 100", sum)
+	}
+	if sum := svc.SumByAccount(4, second.ID); sum != 100 {
+		t.Fatalf("SumByAccount(second) = %d, want 100", sum)
+	}
+}
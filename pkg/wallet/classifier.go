@@ -0,0 +1,135 @@
+package wallet
+
+import (
+	"github.com/Nappy-Says/wallet/pkg/types"
+	"github.com/google/uuid"
+)
+
+// Classifier inspects a sequence of payments on the same account and
+// produces the MultiTransactions it recognizes, e.g. swaps or refunds.
+type Classifier interface {
+	Classify(payments []*types.Payment) []*types.MultiTransaction
+}
+
+// refundClassifier pairs a rejected payment with the payment immediately
+// after it, when that one has the same account, category and amount, and
+// reports the pair as a MultiTransactionRefund. A repeat separated from the
+// rejected payment by any other payment is not paired.
+type refundClassifier struct{}
+
+func (c *refundClassifier) Classify(payments []*types.Payment) []*types.MultiTransaction {
+	result := make([]*types.MultiTransaction, 0)
+
+	for i := 0; i < len(payments)-1; i++ {
+		rejected := payments[i]
+		if rejected.Status != types.PaymentStatusFail {
+			continue
+		}
+
+		retry := payments[i+1]
+		if retry.AccountID != rejected.AccountID || retry.Category != rejected.Category || retry.Amount != rejected.Amount {
+			continue
+		}
+
+		result = append(result, &types.MultiTransaction{
+			ID:         uuid.New().String(),
+			AccountID:  rejected.AccountID,
+			FromAsset:  rejected.Category,
+			ToAsset:    retry.Category,
+			Kind:       types.MultiTransactionRefund,
+			Amount:     retry.Amount,
+			PaymentIDs: []string{rejected.ID, retry.ID},
+		})
+	}
+
+	return result
+}
+
+// swapClassifier recognizes two back-to-back payments on the same account
+// with matching amounts but different categories as a swap between assets.
+type swapClassifier struct{}
+
+func (c *swapClassifier) Classify(payments []*types.Payment) []*types.MultiTransaction {
+	result := make([]*types.MultiTransaction, 0)
+
+	for i := 0; i < len(payments)-1; i++ {
+		first := payments[i]
+		second := payments[i+1]
+
+		if first.Status == types.PaymentStatusFail || second.Status == types.PaymentStatusFail {
+			continue
+		}
+		if second.AccountID != first.AccountID || second.Amount != first.Amount {
+			continue
+		}
+		if second.Category == first.Category {
+			continue
+		}
+
+		result = append(result, &types.MultiTransaction{
+			ID:         uuid.New().String(),
+			AccountID:  first.AccountID,
+			FromAsset:  first.Category,
+			ToAsset:    second.Category,
+			Kind:       types.MultiTransactionSwap,
+			Amount:     first.Amount,
+			PaymentIDs: []string{first.ID, second.ID},
+		})
+	}
+
+	return result
+}
+
+// defaultClassifiers returns the built-in classifiers registered on every
+// Service unless RegisterClassifier has already been called.
+func defaultClassifiers() []Classifier {
+	return []Classifier{&refundClassifier{}, &swapClassifier{}}
+}
+
+// RegisterClassifier adds a Classifier for MultiTransactions to run. Once any
+// classifier has been registered, MultiTransactions runs only the registered
+// classifiers, not the built-ins.
+func (s *Service) RegisterClassifier(classifier Classifier) {
+	s.classifiersMu.Lock()
+	defer s.classifiersMu.Unlock()
+
+	s.classifiers = append(s.classifiers, classifier)
+}
+
+// MultiTransactions classifies the payments of accountID into higher-level
+// activities such as swaps and refunds, caching the result on the Service.
+func (s *Service) MultiTransactions(accountID int64) ([]*types.MultiTransaction, error) {
+	if _, err := s.FindAccountByID(accountID); err != nil {
+		return nil, err
+	}
+
+	payments := make([]*types.Payment, 0)
+	for _, payment := range s.Snapshot().Payments {
+		if payment.AccountID == accountID {
+			payments = append(payments, payment)
+		}
+	}
+
+	s.classifiersMu.Lock()
+	defer s.classifiersMu.Unlock()
+
+	classifiers := s.classifiers
+	if len(classifiers) == 0 {
+		classifiers = defaultClassifiers()
+	}
+
+	result := make([]*types.MultiTransaction, 0)
+	for _, classifier := range classifiers {
+		result = append(result, classifier.Classify(payments)...)
+	}
+
+	kept := make([]*types.MultiTransaction, 0, len(s.multiTransactions))
+	for _, mt := range s.multiTransactions {
+		if mt.AccountID != accountID {
+			kept = append(kept, mt)
+		}
+	}
+	s.multiTransactions = append(kept, result...)
+
+	return result, nil
+}
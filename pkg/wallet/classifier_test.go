@@ -0,0 +1,87 @@
+package wallet
+
+import (
+	"testing"
+
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+func TestRefundClassifier_PairsRejectedPaymentWithRetry(t *testing.T) {
+	payments := []*types.Payment{
+		{ID: "p1", AccountID: 1, Amount: 100, Category: "food", Status: types.PaymentStatusFail},
+		{ID: "p2", AccountID: 1, Amount: 100, Category: "food", Status: types.PaymentStatusOk},
+	}
+
+	result := (&refundClassifier{}).Classify(payments)
+	if len(result) != 1 {
+		t.Fatalf("len(result) = %d, want 1", len(result))
+	}
+
+	mt := result[0]
+	if mt.Kind != types.MultiTransactionRefund {
+		t.Fatalf("Kind = %v, want MultiTransactionRefund", mt.Kind)
+	}
+	if mt.AccountID != 1 || mt.Amount != 100 {
+		t.Fatalf("unexpected MultiTransaction: %+v", mt)
+	}
+	if len(mt.PaymentIDs) != 2 || mt.PaymentIDs[0] != "p1" || mt.PaymentIDs[1] != "p2" {
+		t.Fatalf("PaymentIDs = %v, want [p1 p2]", mt.PaymentIDs)
+	}
+}
+
+func TestSwapClassifier_PairsBackToBackPaymentsAcrossCategories(t *testing.T) {
+	payments := []*types.Payment{
+		{ID: "p1", AccountID: 1, Amount: 50, Category: "usd", Status: types.PaymentStatusOk},
+		{ID: "p2", AccountID: 1, Amount: 50, Category: "eur", Status: types.PaymentStatusOk},
+	}
+
+	result := (&swapClassifier{}).Classify(payments)
+	if len(result) != 1 {
+		t.Fatalf("len(result) = %d, want 1", len(result))
+	}
+
+	mt := result[0]
+	if mt.Kind != types.MultiTransactionSwap {
+		t.Fatalf("Kind = %v, want MultiTransactionSwap", mt.Kind)
+	}
+	if mt.FromAsset != "usd" || mt.ToAsset != "eur" {
+		t.Fatalf("FromAsset/ToAsset = %q/%q, want usd/eur", mt.FromAsset, mt.ToAsset)
+	}
+}
+
+// TestSwapClassifier_IgnoresFailedLeg checks that a failed payment sitting
+// next to a same-amount, different-category payment is not reported as a
+// completed swap, since no funds actually moved for the failed leg.
+func TestSwapClassifier_IgnoresFailedLeg(t *testing.T) {
+	payments := []*types.Payment{
+		{ID: "p1", AccountID: 1, Amount: 50, Category: "usd", Status: types.PaymentStatusFail},
+		{ID: "p2", AccountID: 1, Amount: 50, Category: "eur", Status: types.PaymentStatusOk},
+	}
+
+	result := (&swapClassifier{}).Classify(payments)
+	if len(result) != 0 {
+		t.Fatalf("Classify() = %+v, want no swaps for a failed leg", result)
+	}
+}
+
+func TestService_MultiTransactionsClassifiesRefund(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	svc.addPayment(&types.Payment{ID: "p1", AccountID: account.ID, Amount: 100, Category: "food", Status: types.PaymentStatusFail})
+	svc.addPayment(&types.Payment{ID: "p2", AccountID: account.ID, Amount: 100, Category: "food", Status: types.PaymentStatusOk})
+
+	result, err := svc.MultiTransactions(account.ID)
+	if err != nil {
+		t.Fatalf("MultiTransactions: %v", err)
+	}
+	if len(result) != 1 {
+		t.Fatalf("len(result) = %d, want 1", len(result))
+	}
+	if result[0].Kind != types.MultiTransactionRefund {
+		t.Fatalf("Kind = %v, want MultiTransactionRefund", result[0].Kind)
+	}
+}
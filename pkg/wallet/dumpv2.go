@@ -0,0 +1,139 @@
+package wallet
+
+import (
+	"io/ioutil"
+	"strconv"
+	"strings"
+
+	"github.com/Nappy-Says/wallet/pkg/dump"
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+// CurrentDumpVersion is the dump format version written by ExportV2.
+const CurrentDumpVersion = 1
+
+// DumpOptions configures ExportV2.
+type DumpOptions struct {
+	// Codec is "cbor" or "json". Defaults to "cbor" if empty.
+	Codec string
+}
+
+// dumpV1 is the payload wrapped by the WLT1 container for version 1.
+type dumpV1 struct {
+	Accounts          []*types.Account
+	Payments          []*types.Payment
+	Favorites         []*types.Favorite
+	MultiTransactions []*types.MultiTransaction
+}
+
+func init() {
+	dump.RegisterMigrator(0, 1, migrateDumpV0ToV1)
+}
+
+// migrateDumpV0ToV1 upgrades a file in ExportToFile's original
+// ";"/"|"-delimited, accounts-only format (no WLT1 header) into a V1 WLT1
+// container.
+func migrateDumpV0ToV1(old []byte) ([]byte, error) {
+	records := strings.Split(string(old), "|")
+	if len(records) > 0 {
+		records = records[:len(records)-1]
+	}
+
+	accounts := make([]*types.Account, 0, len(records))
+	for _, record := range records {
+		if record == "" {
+			continue
+		}
+
+		fields := strings.Split(record, ";")
+		id, err := strconv.Atoi(fields[0])
+		if err != nil {
+			return nil, err
+		}
+		balance, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return nil, err
+		}
+
+		accounts = append(accounts, &types.Account{
+			ID:      int64(id),
+			Phone:   types.Phone(fields[1]),
+			Balance: types.Money(balance),
+		})
+	}
+
+	return dump.Encode(1, "json", dumpV1{Accounts: accounts})
+}
+
+// ExportV2 writes the Service's accounts, payments, favorites and
+// multi-transactions to a versioned, self-describing dump file at path.
+// Pairings are not written.
+func (s *Service) ExportV2(path string, opts DumpOptions) error {
+	codec := opts.Codec
+	if codec == "" {
+		codec = "cbor"
+	}
+
+	s.favoritesMu.RLock()
+	favorites := make([]*types.Favorite, len(s.favorites))
+	copy(favorites, s.favorites)
+	s.favoritesMu.RUnlock()
+
+	s.classifiersMu.RLock()
+	multiTransactions := make([]*types.MultiTransaction, len(s.multiTransactions))
+	copy(multiTransactions, s.multiTransactions)
+	s.classifiersMu.RUnlock()
+
+	data, err := dump.Encode(CurrentDumpVersion, codec, dumpV1{
+		Accounts:          s.store().Accounts(),
+		Payments:          s.Snapshot().Payments,
+		Favorites:         favorites,
+		MultiTransactions: multiTransactions,
+	})
+	if err != nil {
+		return err
+	}
+
+	return ioutil.WriteFile(path, data, 0666)
+}
+
+// ImportV2 reads a dump file at path, auto-detecting its version by magic
+// bytes and running any applicable migrators before loading it.
+func (s *Service) ImportV2(path string) error {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	version := dump.DetectVersion(data)
+	if version != CurrentDumpVersion {
+		data, err = dump.Migrate(data, version, CurrentDumpVersion)
+		if err != nil {
+			return err
+		}
+	}
+
+	var payload dumpV1
+	if _, err := dump.Decode(data, &payload); err != nil {
+		return err
+	}
+
+	for _, account := range payload.Accounts {
+		s.store().Put(account)
+	}
+	s.replacePayments(payload.Payments)
+
+	s.favoritesMu.Lock()
+	s.favorites = payload.Favorites
+	s.favoritesByID = make(map[string]*types.Favorite, len(payload.Favorites))
+	for _, f := range payload.Favorites {
+		s.favoritesByID[f.ID] = f
+	}
+	s.favoritesMu.Unlock()
+
+	s.classifiersMu.Lock()
+	s.multiTransactions = payload.MultiTransactions
+	s.classifiersMu.Unlock()
+
+	return nil
+}
@@ -0,0 +1,41 @@
+package wallet
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// TestService_ImportV2MigratesV0Format writes a dump in the original,
+// headerless ExportToFile format and checks ImportV2 auto-detects it as V0
+// and runs the registered V0->V1 migrator before loading it.
+func TestService_ImportV2MigratesV0Format(t *testing.T) {
+	src := NewService(nil)
+	account, err := src.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := src.Deposit(account.ID, 500); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	path := filepath.Join(t.TempDir(), "accounts.dump")
+	if err := src.ExportToFile(path); err != nil {
+		t.Fatalf("ExportToFile: %v", err)
+	}
+
+	dst := NewService(nil)
+	if err := dst.ImportV2(path); err != nil {
+		t.Fatalf("ImportV2: %v", err)
+	}
+
+	imported, err := dst.FindAccountByID(account.ID)
+	if err != nil {
+		t.Fatalf("FindAccountByID(%d): %v", account.ID, err)
+	}
+	if imported.Phone != account.Phone {
+		t.Fatalf("imported.Phone = %q, want %q", imported.Phone, account.Phone)
+	}
+	if imported.Balance != account.Balance {
+		t.Fatalf("imported.Balance = %d, want %d", imported.Balance, account.Balance)
+	}
+}
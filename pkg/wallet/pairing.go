@@ -0,0 +1,188 @@
+package wallet
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Nappy-Says/wallet/pkg/session"
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+const pairingExpiryCheckInterval = time.Minute
+
+// pairings lazily creates the Service's session.Manager so that a
+// zero-value Service stays usable without an explicit constructor. The lazy
+// init is guarded by pairingManagerOnce so concurrent first calls can't race
+// to create (and leak the janitor goroutine of) two different Managers.
+func (s *Service) pairings() *session.Manager {
+	s.pairingManagerOnce.Do(func() {
+		s.pairingManager = session.NewManager(pairingExpiryCheckInterval)
+	})
+	return s.pairingManager
+}
+
+// Close stops the background janitor goroutine started by any pairing
+// operation on s. Callers that use pairings must call Close when done with
+// the Service, or the janitor leaks for the process lifetime.
+func (s *Service) Close() {
+	s.pairings().Close()
+}
+
+// ProposePairing requests a temporary, scoped authorization for an external
+// app to act on accountID without holding its credentials.
+func (s *Service) ProposePairing(accountID int64, permissions session.Permissions, ttl time.Duration) (*session.Pairing, error) {
+	if _, err := s.FindAccountByID(accountID); err != nil {
+		return nil, err
+	}
+
+	return s.pairings().Propose(accountID, permissions, ttl)
+}
+
+// ApprovePairing marks pairingID as approved by the account holder.
+func (s *Service) ApprovePairing(pairingID string) error {
+	return s.pairings().Approve(pairingID)
+}
+
+// RecordSuccessfulPairing records a successful spend of amount against
+// pairingID, counting it towards the pairing's spend cap. Use this when a
+// payment was made by some means other than PayViaPairing (which already
+// records its own spend) but should still count against the pairing.
+func (s *Service) RecordSuccessfulPairing(pairingID string, amount types.Money) error {
+	return s.pairings().RecordSuccessful(pairingID, amount)
+}
+
+// HasActivePairings reports whether accountID has an approved, unexpired
+// pairing.
+func (s *Service) HasActivePairings(accountID int64) (bool, error) {
+	if _, err := s.FindAccountByID(accountID); err != nil {
+		return false, err
+	}
+
+	return s.pairings().HasActive(accountID), nil
+}
+
+// PayViaPairing pays amount in category on behalf of pairingID's account,
+// enforcing the pairing's spend cap, category whitelist and expiry. The
+// check and the spend-cap bookkeeping happen atomically in Reserve, so two
+// concurrent calls against the same pairing can't both pass the cap check
+// before either commits; if the payment itself then fails, Release gives
+// the reservation back.
+func (s *Service) PayViaPairing(pairingID string, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	pairing, err := s.pairings().Reserve(pairingID, amount, category)
+	if err != nil {
+		return nil, err
+	}
+
+	payment, err := s.Pay(pairing.AccountID, amount, category)
+	if err != nil {
+		s.pairings().Release(pairingID, amount)
+		return nil, err
+	}
+
+	return payment, nil
+}
+
+func exportPairings(dir string, pairings []*session.Pairing) error {
+	if len(pairings) == 0 {
+		return nil
+	}
+
+	file, err := os.OpenFile(dir+"/pairings.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	defer func() {
+		if cerr := file.Close(); cerr != nil {
+			log.Print(cerr)
+		}
+	}()
+
+	str := ""
+	for _, p := range pairings {
+		categories := make([]string, 0, len(p.Permissions.Categories))
+		for _, c := range p.Permissions.Categories {
+			categories = append(categories, string(c))
+		}
+		str += p.ID + ";" +
+			fmt.Sprint(p.AccountID) + ";" +
+			fmt.Sprint(p.Permissions.MaxAmount) + ";" +
+			strings.Join(categories, ",") + ";" +
+			p.ExpiresAt.Format(time.RFC3339) + ";" +
+			fmt.Sprint(p.Approved) + ";" +
+			fmt.Sprint(p.Spent) + "\n"
+	}
+
+	_, err = file.WriteString(str)
+	return err
+}
+
+func importPairings(dir string) ([]*session.Pairing, error) {
+	path := dir + "/pairings.dump"
+	if _, err := os.Stat(path); err != nil {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.Split(string(content), "\n")
+	if len(lines) > 0 {
+		lines = lines[:len(lines)-1]
+	}
+
+	result := make([]*session.Pairing, 0, len(lines))
+	for _, line := range lines {
+		fields := strings.Split(line, ";")
+
+		accountID, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		maxAmount, err := strconv.ParseInt(fields[2], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		var categories []types.PaymentCategory
+		if fields[3] != "" {
+			for _, c := range strings.Split(fields[3], ",") {
+				categories = append(categories, types.PaymentCategory(c))
+			}
+		}
+
+		expiresAt, err := time.Parse(time.RFC3339, fields[4])
+		if err != nil {
+			return nil, err
+		}
+		approved, err := strconv.ParseBool(fields[5])
+		if err != nil {
+			return nil, err
+		}
+		spent, err := strconv.ParseInt(fields[6], 10, 64)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, &session.Pairing{
+			ID:        fields[0],
+			AccountID: accountID,
+			Permissions: session.Permissions{
+				MaxAmount:  types.Money(maxAmount),
+				Categories: categories,
+			},
+			ExpiresAt: expiresAt,
+			Approved:  approved,
+			Spent:     types.Money(spent),
+		})
+	}
+
+	return result, nil
+}
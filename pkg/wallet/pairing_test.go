@@ -0,0 +1,130 @@
+package wallet
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Nappy-Says/wallet/pkg/session"
+)
+
+// TestService_ConcurrentCloseAndProposePairing runs Close against the first
+// call to a pairing method from many goroutines at once; run with -race to
+// confirm Close forces the pairingManagerOnce init through pairings()
+// instead of racing a concurrent writer of s.pairingManager.
+func TestService_ConcurrentCloseAndProposePairing(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+
+	const callers = 20
+	var wg sync.WaitGroup
+	wg.Add(callers + 1)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			svc.ProposePairing(account.ID, session.Permissions{}, time.Minute)
+		}()
+	}
+	go func() {
+		defer wg.Done()
+		svc.Close()
+	}()
+	wg.Wait()
+}
+
+func TestService_PayViaPairingEnforcesSpendCap(t *testing.T) {
+	svc := NewService(nil)
+	defer svc.Close()
+
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	pairing, err := svc.ProposePairing(account.ID, session.Permissions{MaxAmount: 100}, time.Minute)
+	if err != nil {
+		t.Fatalf("ProposePairing: %v", err)
+	}
+
+	if _, err := svc.PayViaPairing(pairing.ID, 10, "food"); err != session.ErrPairingNotApproved {
+		t.Fatalf("PayViaPairing before approval: got %v, want ErrPairingNotApproved", err)
+	}
+
+	if err := svc.ApprovePairing(pairing.ID); err != nil {
+		t.Fatalf("ApprovePairing: %v", err)
+	}
+
+	if _, err := svc.PayViaPairing(pairing.ID, 60, "food"); err != nil {
+		t.Fatalf("PayViaPairing within cap: %v", err)
+	}
+	if _, err := svc.PayViaPairing(pairing.ID, 60, "food"); err != session.ErrSpendCapExceeded {
+		t.Fatalf("PayViaPairing over cap: got %v, want ErrSpendCapExceeded", err)
+	}
+
+	active, err := svc.HasActivePairings(account.ID)
+	if err != nil {
+		t.Fatalf("HasActivePairings: %v", err)
+	}
+	if !active {
+		t.Fatalf("HasActivePairings() = false, want true")
+	}
+
+	// 60 already spent; RecordSuccessfulPairing should count its amount
+	// towards the same cap, not silently record zero.
+	if err := svc.RecordSuccessfulPairing(pairing.ID, 40); err != nil {
+		t.Fatalf("RecordSuccessfulPairing: %v", err)
+	}
+	if _, err := svc.PayViaPairing(pairing.ID, 1, "food"); err != session.ErrSpendCapExceeded {
+		t.Fatalf("PayViaPairing after RecordSuccessfulPairing: got %v, want ErrSpendCapExceeded", err)
+	}
+}
+
+// TestService_PayViaPairingConcurrentRespectsSpendCap runs many concurrent
+// PayViaPairing calls against a pairing whose cap only admits one of them,
+// to catch the TOCTOU between checking the cap and recording the spend:
+// run with -race.
+func TestService_PayViaPairingConcurrentRespectsSpendCap(t *testing.T) {
+	svc := NewService(nil)
+	defer svc.Close()
+
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 10_000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	pairing, err := svc.ProposePairing(account.ID, session.Permissions{MaxAmount: 100}, time.Minute)
+	if err != nil {
+		t.Fatalf("ProposePairing: %v", err)
+	}
+	if err := svc.ApprovePairing(pairing.ID); err != nil {
+		t.Fatalf("ApprovePairing: %v", err)
+	}
+
+	const callers = 20
+	var succeeded int64
+	var wg sync.WaitGroup
+	wg.Add(callers)
+	for i := 0; i < callers; i++ {
+		go func() {
+			defer wg.Done()
+			if _, err := svc.PayViaPairing(pairing.ID, 60, "food"); err == nil {
+				atomic.AddInt64(&succeeded, 1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	if succeeded > 1 {
+		t.Fatalf("PayViaPairing let %d concurrent calls through a cap of 100 at 60 each, want at most 1", succeeded)
+	}
+}
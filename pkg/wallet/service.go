@@ -10,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"github.com/Nappy-Says/wallet/pkg/session"
+	"github.com/Nappy-Says/wallet/pkg/store"
 	"github.com/Nappy-Says/wallet/pkg/types"
 	"github.com/google/uuid"
 )
@@ -25,51 +27,187 @@ var ErrFileNotFound = errors.New("file not fount")
 var err error
 
 type Service struct {
-	nextAccountID int64
-	accounts      []*types.Account
-	payments      []*types.Payment
+	accountStoreOnce sync.Once
+	accountStore     store.AccountStore
+
+	paymentsMu   sync.RWMutex
+	payments     []*types.Payment
+	paymentsByID map[string]*types.Payment
+
+	favoritesMu   sync.RWMutex
 	favorites     []*types.Favorite
+	favoritesByID map[string]*types.Favorite
+
+	classifiersMu     sync.RWMutex
+	classifiers       []Classifier
+	multiTransactions []*types.MultiTransaction
+
+	pairingManagerOnce sync.Once
+	pairingManager     *session.Manager
 }
 
-func (s *Service) RegisterAccount(phone types.Phone) (*types.Account, error) {
-	for _, account := range s.accounts {
-		if account.Phone == phone {
-			return nil, ErrPhoneRegistered
+// Snapshot is an immutable, point-in-time copy of a Service's payments,
+// safe for parallel aggregators like SumPayments to read concurrently
+// without holding the Service's lock for the duration of the computation.
+type Snapshot struct {
+	Payments []*types.Payment
+}
+
+// Snapshot copies the current payments under a read lock and returns them
+// for lock-free concurrent reading. Each payment is copied by value, not
+// just its pointer, since fields like Status keep changing (e.g. via
+// Reject) after the payment is created.
+func (s *Service) Snapshot() Snapshot {
+	s.paymentsMu.RLock()
+	defer s.paymentsMu.RUnlock()
+
+	payments := make([]*types.Payment, len(s.payments))
+	for i, payment := range s.payments {
+		copied := *payment
+		payments[i] = &copied
+	}
+	return Snapshot{Payments: payments}
+}
+
+// addPayment appends payment, keeping paymentsByID in sync.
+func (s *Service) addPayment(payment *types.Payment) {
+	s.paymentsMu.Lock()
+	defer s.paymentsMu.Unlock()
+
+	if s.paymentsByID == nil {
+		s.paymentsByID = make(map[string]*types.Payment, len(s.payments))
+	}
+	s.payments = append(s.payments, payment)
+	s.paymentsByID[payment.ID] = payment
+}
+
+// upsertPayment updates payment in place if one with the same ID already
+// exists, or appends it otherwise, keeping paymentsByID in sync either way.
+func (s *Service) upsertPayment(payment *types.Payment) {
+	s.paymentsMu.Lock()
+	defer s.paymentsMu.Unlock()
+
+	if s.paymentsByID == nil {
+		s.paymentsByID = make(map[string]*types.Payment, len(s.payments))
+		for _, p := range s.payments {
+			s.paymentsByID[p.ID] = p
 		}
 	}
-	s.nextAccountID++
-	account := &types.Account{
-		ID:      s.nextAccountID,
-		Phone:   phone,
-		Balance: 0,
+
+	if existing, ok := s.paymentsByID[payment.ID]; ok {
+		*existing = *payment
+		return
 	}
-	s.accounts = append(s.accounts, account)
-	return account, nil
+	s.payments = append(s.payments, payment)
+	s.paymentsByID[payment.ID] = payment
 }
 
-func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
-	if amount <= 0 {
-		return nil, ErrAmountMustBePositive
+// replacePayments swaps in a whole new set of payments, as when restoring
+// from a dump.
+func (s *Service) replacePayments(payments []*types.Payment) {
+	s.paymentsMu.Lock()
+	defer s.paymentsMu.Unlock()
+
+	s.payments = payments
+	s.paymentsByID = make(map[string]*types.Payment, len(payments))
+	for _, p := range payments {
+		s.paymentsByID[p.ID] = p
 	}
+}
 
-	var account *types.Account
+// addFavorite appends favorite, keeping favoritesByID in sync.
+func (s *Service) addFavorite(favorite *types.Favorite) {
+	s.favoritesMu.Lock()
+	defer s.favoritesMu.Unlock()
 
-	for _, acc := range s.accounts {
-		if acc.ID == accountID {
-			account = acc
-			break
+	if s.favoritesByID == nil {
+		s.favoritesByID = make(map[string]*types.Favorite, len(s.favorites))
+	}
+	s.favorites = append(s.favorites, favorite)
+	s.favoritesByID[favorite.ID] = favorite
+}
+
+// upsertFavorite updates favorite in place if one with the same ID already
+// exists, or appends it otherwise, keeping favoritesByID in sync either way.
+func (s *Service) upsertFavorite(favorite *types.Favorite) {
+	s.favoritesMu.Lock()
+	defer s.favoritesMu.Unlock()
+
+	if s.favoritesByID == nil {
+		s.favoritesByID = make(map[string]*types.Favorite, len(s.favorites))
+		for _, f := range s.favorites {
+			s.favoritesByID[f.ID] = f
 		}
 	}
 
-	if account == nil {
-		return nil, ErrAccountNotFound
+	if existing, ok := s.favoritesByID[favorite.ID]; ok {
+		*existing = *favorite
+		return
+	}
+	s.favorites = append(s.favorites, favorite)
+	s.favoritesByID[favorite.ID] = favorite
+}
+
+// NewService constructs a Service backed by accountStore, e.g. a
+// store.NDStore, store.HDStore or store.DistributedStore.
+func NewService(accountStore store.AccountStore) *Service {
+	return &Service{accountStore: accountStore}
+}
+
+// store lazily creates a flat NDStore so that a zero-value Service, as
+// produced by &Service{}, stays usable without calling NewService. The
+// lazy init is guarded by accountStoreOnce so concurrent first calls can't
+// race to create (and then discard) two different stores.
+func (s *Service) store() store.AccountStore {
+	s.accountStoreOnce.Do(func() {
+		if s.accountStore == nil {
+			s.accountStore = store.NewNDStore()
+		}
+	})
+	return s.accountStore
+}
+
+func (s *Service) RegisterAccount(phone types.Phone) (*types.Account, error) {
+	account, err := s.store().Register(phone)
+	if err == store.ErrPhoneRegistered {
+		return nil, ErrPhoneRegistered
+	}
+	return account, err
+}
+
+func (s *Service) Pay(accountID int64, amount types.Money, category types.PaymentCategory) (*types.Payment, error) {
+	return s.pay(accountID, amount, category, nil)
+}
+
+// PayWithShares pays like Pay, but additionally enforces shares against a
+// DistributedStore-backed account, requiring at least K distinct shares
+// before the balance is decremented. Against any other store backend it
+// behaves exactly like Pay.
+func (s *Service) PayWithShares(accountID int64, amount types.Money, category types.PaymentCategory, shares [][]byte) (*types.Payment, error) {
+	return s.pay(accountID, amount, category, shares)
+}
+
+func (s *Service) pay(accountID int64, amount types.Money, category types.PaymentCategory, shares [][]byte) (*types.Payment, error) {
+	if amount <= 0 {
+		return nil, ErrAmountMustBePositive
 	}
 
-	if account.Balance < amount {
-		return nil, ErrNotEnoughtBalance
+	if _, err := s.FindAccountByID(accountID); err != nil {
+		return nil, err
 	}
 
-	account.Balance -= amount
+	if distributed, ok := s.store().(*store.DistributedStore); ok {
+		if err := distributed.Authorize(shares); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := s.store().Withdraw(accountID, amount); err != nil {
+		if err == store.ErrNotEnoughBalance {
+			return nil, ErrNotEnoughtBalance
+		}
+		return nil, err
+	}
 
 	paymentID := uuid.New().String()
 	payment := &types.Payment{
@@ -80,44 +218,63 @@ func (s *Service) Pay(accountID int64, amount types.Money, category types.Paymen
 		Status:    types.PaymentStatusInProgress,
 	}
 
-	s.payments = append(s.payments, payment)
+	s.addPayment(payment)
 	return payment, nil
 }
 
 func (s *Service) FindAccountByID(accountID int64) (*types.Account, error) {
-	var account *types.Account
-
-	for _, acc := range s.accounts {
-		if acc.ID == accountID {
-			account = acc
-			break
-		}
-	}
-
-	if account == nil {
+	account, err := s.store().FindByID(accountID)
+	if err == store.ErrAccountNotFound {
 		return nil, ErrAccountNotFound
 	}
-
-	return account, nil
+	return account, err
 }
 
-func (s *Service) FindPaymentByID(paymentID string) (*types.Payment, error) {
-	var payment *types.Payment
+// findPaymentLocked returns the live payment matching paymentID. Callers
+// must hold paymentsMu and must not let the returned pointer escape their
+// own critical section, since its fields (e.g. Status) can keep changing
+// after the call returns.
+func (s *Service) findPaymentLocked(paymentID string) (*types.Payment, error) {
+	if s.paymentsByID != nil {
+		if payment, ok := s.paymentsByID[paymentID]; ok {
+			return payment, nil
+		}
+		return nil, ErrPaymentNotFound
+	}
 
 	for _, pay := range s.payments {
 		if pay.ID == paymentID {
-			payment = pay
+			return pay, nil
 		}
 	}
+	return nil, ErrPaymentNotFound
+}
 
-	if payment == nil {
-		return nil, ErrPaymentNotFound
-	}
+// FindPaymentByID returns a copy of the payment matching paymentID, safe
+// for the caller to read without racing later updates like Reject.
+func (s *Service) FindPaymentByID(paymentID string) (*types.Payment, error) {
+	s.paymentsMu.RLock()
+	defer s.paymentsMu.RUnlock()
 
-	return payment, nil
+	payment, err := s.findPaymentLocked(paymentID)
+	if err != nil {
+		return nil, err
+	}
+	copied := *payment
+	return &copied, nil
 }
 
 func (s *Service) FindFavoriteByID(favoriteID string) (*types.Favorite, error) {
+	s.favoritesMu.RLock()
+	defer s.favoritesMu.RUnlock()
+
+	if s.favoritesByID != nil {
+		if favorite, ok := s.favoritesByID[favoriteID]; ok {
+			return favorite, nil
+		}
+		return nil, ErrFavoriteNotFound
+	}
+
 	for _, favorite := range s.favorites {
 		if favorite.ID == favoriteID {
 			return favorite, nil
@@ -131,28 +288,33 @@ func (s *Service) Deposit(accountID int64, amount types.Money) error {
 		return ErrAmountMustBePositive
 	}
 
-	account, err := s.FindAccountByID(accountID)
-	if err != nil {
-		return err
+	err := s.store().Deposit(accountID, amount)
+	if err == store.ErrAccountNotFound {
+		return ErrAccountNotFound
 	}
-
-	account.Balance += amount
-	return nil
+	return err
 }
 
 func (s *Service) Reject(paymentID string) error {
-	pay, err := s.FindPaymentByID(paymentID)
+	s.paymentsMu.Lock()
+	pay, err := s.findPaymentLocked(paymentID)
 	if err != nil {
+		s.paymentsMu.Unlock()
 		return err
 	}
+	accountID, amount := pay.AccountID, pay.Amount
+	s.paymentsMu.Unlock()
 
-	acc, err := s.FindAccountByID(pay.AccountID)
-	if err != nil {
+	if err := s.store().Deposit(accountID, amount); err != nil {
+		if err == store.ErrAccountNotFound {
+			return ErrAccountNotFound
+		}
 		return err
 	}
 
+	s.paymentsMu.Lock()
 	pay.Status = types.PaymentStatusFail
-	acc.Balance += pay.Amount
+	s.paymentsMu.Unlock()
 
 	return nil
 }
@@ -187,7 +349,7 @@ func (s *Service) FavoritePayment(paymentID string, name string) (*types.Favorit
 		Category:  payment.Category,
 	}
 
-	s.favorites = append(s.favorites, newFavorite)
+	s.addFavorite(newFavorite)
 	return newFavorite, nil
 }
 
@@ -219,7 +381,7 @@ func (s *Service) ExportToFile(path string) error {
 
 	str := ""
 
-	for _, acc := range s.accounts {
+	for _, acc := range s.store().Accounts() {
 		ID := strconv.Itoa(int(acc.ID)) + ";"
 		phone := string(acc.Phone) + ";"
 		balance := strconv.Itoa(int(acc.Balance))
@@ -288,14 +450,15 @@ func (s *Service) ImportFromFile(path string) error {
 			Balance: types.Money(balance),
 		}
 
-		s.accounts = append(s.accounts, newAccount)
+		s.store().Put(newAccount)
 	}
 
 	return nil
 }
 
 func (s *Service) Export(dir string) error {
-	if len(s.accounts) > 0 {
+	accounts := s.store().Accounts()
+	if len(accounts) > 0 {
 		file, err := os.OpenFile(dir+"/accounts.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
 
 		defer func() {
@@ -308,12 +471,13 @@ func (s *Service) Export(dir string) error {
 		}()
 
 		str := ""
-		for _, v := range s.accounts {
+		for _, v := range accounts {
 			str += fmt.Sprint(v.ID) + ";" + string(v.Phone) + ";" + fmt.Sprint(v.Balance) + "\n"
 		}
 		file.WriteString(str)
 	}
-	if len(s.payments) > 0 {
+	payments := s.Snapshot().Payments
+	if len(payments) > 0 {
 		file, err := os.OpenFile(dir+"/payments.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
 
 		defer func() {
@@ -326,13 +490,18 @@ func (s *Service) Export(dir string) error {
 		}()
 
 		str := ""
-		for _, v := range s.payments {
+		for _, v := range payments {
 			str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + ";" + fmt.Sprint(v.Status) + "\n"
 		}
 		file.WriteString(str)
 	}
 
-	if len(s.favorites) > 0 {
+	s.favoritesMu.RLock()
+	favorites := make([]*types.Favorite, len(s.favorites))
+	copy(favorites, s.favorites)
+	s.favoritesMu.RUnlock()
+
+	if len(favorites) > 0 {
 		file, err := os.OpenFile(dir+"/favorites.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
 
 		defer func() {
@@ -345,12 +514,42 @@ func (s *Service) Export(dir string) error {
 		}()
 
 		str := ""
-		for _, v := range s.favorites {
+		for _, v := range favorites {
 			str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.Amount) + ";" + fmt.Sprint(v.Category) + "\n"
 		}
 		file.WriteString(str)
 	}
 
+	s.classifiersMu.RLock()
+	multiTransactions := make([]*types.MultiTransaction, len(s.multiTransactions))
+	copy(multiTransactions, s.multiTransactions)
+	s.classifiersMu.RUnlock()
+
+	if len(multiTransactions) > 0 {
+		file, err := os.OpenFile(dir+"/multitransactions.dump", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0666)
+
+		defer func() {
+			if cerr := file.Close(); cerr != nil {
+				if err != nil {
+					err = cerr
+					log.Print(err)
+				}
+			}
+		}()
+
+		str := ""
+		for _, v := range multiTransactions {
+			str += fmt.Sprint(v.ID) + ";" + fmt.Sprint(v.AccountID) + ";" + fmt.Sprint(v.FromAsset) + ";" + fmt.Sprint(v.ToAsset) + ";" + fmt.Sprint(int(v.Kind)) + ";" + fmt.Sprint(v.Amount) + ";" + strings.Join(v.PaymentIDs, ",") + "\n"
+		}
+		file.WriteString(str)
+	}
+
+	if s.pairingManager != nil {
+		if err := exportPairings(dir, s.pairingManager.All()); err != nil {
+			return err
+		}
+	}
+
 	return nil
 }
 
@@ -380,22 +579,12 @@ func (s *Service) Import(dir string) error {
 			if err != nil {
 				return err
 			}
-			flag := true
-			for _, v := range s.accounts {
-				if v.ID == id {
-					v.Phone = types.Phone(strArrAcount[1])
-					v.Balance = types.Money(balance)
-					flag = false
-				}
-			}
-			if flag {
-				account := &types.Account{
-					ID:      id,
-					Phone:   types.Phone(strArrAcount[1]),
-					Balance: types.Money(balance),
-				}
-				s.accounts = append(s.accounts, account)
-			}
+
+			s.store().Put(&types.Account{
+				ID:      id,
+				Phone:   types.Phone(strArrAcount[1]),
+				Balance: types.Money(balance),
+			})
 		}
 	}
 
@@ -427,26 +616,13 @@ func (s *Service) Import(dir string) error {
 			if err != nil {
 				return err
 			}
-			flag := true
-			for _, v := range s.payments {
-				if v.ID == id {
-					v.AccountID = aid
-					v.Amount = types.Money(amount)
-					v.Category = types.PaymentCategory(strArrAcount[3])
-					v.Status = types.PaymentStatus(strArrAcount[4])
-					flag = false
-				}
-			}
-			if flag {
-				data := &types.Payment{
-					ID:        id,
-					AccountID: aid,
-					Amount:    types.Money(amount),
-					Category:  types.PaymentCategory(strArrAcount[3]),
-					Status:    types.PaymentStatus(strArrAcount[4]),
-				}
-				s.payments = append(s.payments, data)
-			}
+			s.upsertPayment(&types.Payment{
+				ID:        id,
+				AccountID: aid,
+				Amount:    types.Money(amount),
+				Category:  types.PaymentCategory(strArrAcount[3]),
+				Status:    types.PaymentStatus(strArrAcount[4]),
+			})
 		}
 	}
 
@@ -478,69 +654,83 @@ func (s *Service) Import(dir string) error {
 			if err != nil {
 				return err
 			}
-			flag := true
-			for _, v := range s.favorites {
-				if v.ID == id {
-					v.AccountID = aid
-					v.Amount = types.Money(amount)
-					v.Category = types.PaymentCategory(strArrAcount[3])
-					flag = false
-				}
-			}
-			if flag {
-				data := &types.Favorite{
-					ID:        id,
-					AccountID: aid,
-					Amount:    types.Money(amount),
-					Category:  types.PaymentCategory(strArrAcount[3]),
-				}
-				s.favorites = append(s.favorites, data)
-			}
+			s.upsertFavorite(&types.Favorite{
+				ID:        id,
+				AccountID: aid,
+				Amount:    types.Money(amount),
+				Category:  types.PaymentCategory(strArrAcount[3]),
+			})
 		}
 	}
 
-	return nil
-}
+	_, err3 := os.Stat(dir + "/multitransactions.dump")
+
+	if err3 == nil {
+		content, err := ioutil.ReadFile(dir + "/multitransactions.dump")
+		if err != nil {
+			return err
+		}
+
+		strArray := strings.Split(string(content), "\n")
+		if len(strArray) > 0 {
+			strArray = strArray[:len(strArray)-1]
+		}
+
+		s.classifiersMu.Lock()
+		err = func() error {
+			for _, v := range strArray {
+				strArrAcount := strings.Split(v, ";")
+				fmt.Println(strArrAcount)
+
+				id := strArrAcount[0]
+				aid, err := strconv.ParseInt(strArrAcount[1], 10, 64)
+				if err != nil {
+					return err
+				}
+				kind, err := strconv.Atoi(strArrAcount[4])
+				if err != nil {
+					return err
+				}
+				amount, err := strconv.ParseInt(strArrAcount[5], 10, 64)
+				if err != nil {
+					return err
+				}
 
-func (s *Service) SumPayments(goroutines int) types.Money {
-	wg := sync.WaitGroup{}
-	mu := sync.Mutex{}
-	sum := int64(0)
-	kol := 0
-	i := 0
-	if goroutines == 0 {
-		kol = len(s.payments)
-	} else {
-		kol = int(len(s.payments) / goroutines)
-	}
-	for i = 0; i < goroutines-1; i++ {
-		wg.Add(1)
-		go func(index int) {
-			defer wg.Done()
-			val := int64(0)
-			payments := s.payments[index*kol : (index+1)*kol]
-			for _, payment := range payments {
-				val += int64(payment.Amount)
+				flag := true
+				for _, v := range s.multiTransactions {
+					if v.ID == id {
+						flag = false
+					}
+				}
+				if flag {
+					data := &types.MultiTransaction{
+						ID:         id,
+						AccountID:  aid,
+						FromAsset:  types.PaymentCategory(strArrAcount[2]),
+						ToAsset:    types.PaymentCategory(strArrAcount[3]),
+						Kind:       types.MultiTransactionKind(kind),
+						Amount:     types.Money(amount),
+						PaymentIDs: strings.Split(strArrAcount[6], ","),
+					}
+					s.multiTransactions = append(s.multiTransactions, data)
+				}
 			}
-			mu.Lock()
-			sum += val
-			mu.Unlock()
+			return nil
+		}()
+		s.classifiersMu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
 
-		}(i)
+	pairings, err := importPairings(dir)
+	if err != nil {
+		return err
+	}
+	if len(pairings) > 0 {
+		s.pairings().Restore(pairings)
 	}
-	wg.Add(1)
-	go func() {
-		defer wg.Done()
-		val := int64(0)
-		payments := s.payments[i*kol:]
-		for _, payment := range payments {
-			val += int64(payment.Amount)
-		}
-		mu.Lock()
-		sum += val
-		mu.Unlock()
 
-	}()
-	wg.Wait()
-	return types.Money(sum)
+	return nil
 }
+
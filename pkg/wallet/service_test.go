@@ -0,0 +1,184 @@
+package wallet
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/Nappy-Says/wallet/pkg/store"
+	"github.com/Nappy-Says/wallet/pkg/types"
+)
+
+// TestService_ConcurrentPayAndSumPayments exercises Pay and SumPayments from
+// many goroutines at once; run with -race to confirm Snapshot keeps
+// SumPayments from reading s.payments while it's being appended to.
+func TestService_ConcurrentPayAndSumPayments(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1_000_000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	const writers = 50
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func() {
+			defer wg.Done()
+			svc.Pay(account.ID, 1, types.PaymentCategory("food"))
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				svc.SumPayments(4)
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+
+	if sum := svc.SumPayments(4); sum != writers {
+		t.Fatalf("SumPayments() = %d, want %d", sum, writers)
+	}
+}
+
+// TestService_ConcurrentPayAndFindAccountByID exercises Pay (which writes
+// Balance via the store) against FindAccountByID (which reads it) from many
+// goroutines at once; run with -race to confirm FindAccountByID returns a
+// copy rather than the store's live *types.Account.
+func TestService_ConcurrentPayAndFindAccountByID(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1_000_000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	const writers = 50
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for i := 0; i < writers; i++ {
+		go func() {
+			defer wg.Done()
+			svc.Pay(account.ID, 1, types.PaymentCategory("food"))
+		}()
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				acc, err := svc.FindAccountByID(account.ID)
+				if err == nil {
+					_ = acc.Balance
+				}
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+}
+
+// TestService_ConcurrentRejectAndMultiTransactions exercises Reject (which
+// writes a payment's Status) against MultiTransactions (which reads it via
+// refundClassifier) from many goroutines at once; run with -race to confirm
+// Snapshot copies each payment by value rather than sharing the live
+// *types.Payment Reject mutates.
+func TestService_ConcurrentRejectAndMultiTransactions(t *testing.T) {
+	svc := NewService(nil)
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1_000_000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	const writers = 50
+	payments := make([]*types.Payment, writers)
+	for i := 0; i < writers; i++ {
+		payment, err := svc.Pay(account.ID, 1, types.PaymentCategory("food"))
+		if err != nil {
+			t.Fatalf("Pay: %v", err)
+		}
+		payments[i] = payment
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(writers)
+	for _, payment := range payments {
+		go func(paymentID string) {
+			defer wg.Done()
+			svc.Reject(paymentID)
+		}(payment.ID)
+	}
+
+	done := make(chan struct{})
+	go func() {
+		for {
+			select {
+			case <-done:
+				return
+			default:
+				svc.MultiTransactions(account.ID)
+			}
+		}
+	}()
+
+	wg.Wait()
+	close(done)
+}
+
+// TestService_PayWithSharesEnforcesThreshold checks that PayWithShares
+// against a DistributedStore requires at least K distinct signature shares
+// before decrementing the balance, and that distinct shares are accepted.
+func TestService_PayWithSharesEnforcesThreshold(t *testing.T) {
+	svc := NewService(store.NewDistributedStore(3, 2))
+	account, err := svc.RegisterAccount("+992000000001")
+	if err != nil {
+		t.Fatalf("RegisterAccount: %v", err)
+	}
+	if err := svc.Deposit(account.ID, 1000); err != nil {
+		t.Fatalf("Deposit: %v", err)
+	}
+
+	if _, err := svc.PayWithShares(account.ID, 100, "food", [][]byte{[]byte("share-a")}); err != store.ErrNotEnoughShares {
+		t.Fatalf("PayWithShares with 1 share: got %v, want ErrNotEnoughShares", err)
+	}
+
+	acc, err := svc.FindAccountByID(account.ID)
+	if err != nil {
+		t.Fatalf("FindAccountByID: %v", err)
+	}
+	if acc.Balance != 1000 {
+		t.Fatalf("balance after rejected PayWithShares = %d, want 1000 (unchanged)", acc.Balance)
+	}
+
+	shares := [][]byte{[]byte("share-a"), []byte("share-b")}
+	if _, err := svc.PayWithShares(account.ID, 100, "food", shares); err != nil {
+		t.Fatalf("PayWithShares with 2 distinct shares: %v", err)
+	}
+
+	acc, err = svc.FindAccountByID(account.ID)
+	if err != nil {
+		t.Fatalf("FindAccountByID: %v", err)
+	}
+	if acc.Balance != 900 {
+		t.Fatalf("balance after PayWithShares = %d, want 900", acc.Balance)
+	}
+}